@@ -0,0 +1,161 @@
+// Package wireproto is the wire protocol shared by execTransport (the host
+// side, in plugin_manager) and plugin_manager/rpc (the child side): a
+// length-prefixed JSON frame carrying a call request or response, plus the
+// argument marshaling those frames need. It exists so the two sides can't
+// drift out of sync with each other.
+package wireproto
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Request is the only message shape sent host-to-child on the wire.
+//
+//	{"op":"call","fn":"Foo","args":[...]}  ->  {"ret":[...],"err":"..."}
+//	{"op":"register"}                      ->  {"ret":[name,version,pkgPath]}
+type Request struct {
+	Op   string        `json:"op"`
+	Fn   string        `json:"fn,omitempty"`
+	Args []interface{} `json:"args,omitempty"`
+}
+
+// Response is the only message shape sent child-to-host on the wire.
+type Response struct {
+	Ret []interface{} `json:"ret,omitempty"`
+	Err string        `json:"err,omitempty"`
+}
+
+// WriteFrame writes v as a netstring-style 4-byte big-endian length prefix
+// followed by that many bytes of JSON.
+func WriteFrame(w io.Writer, v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var lenPrefix [4]byte
+	binary.BigEndian.PutUint32(lenPrefix[:], uint32(len(b)))
+	if _, err := w.Write(lenPrefix[:]); err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+// ReadFrame is WriteFrame's inverse.
+func ReadFrame(r *bufio.Reader, v interface{}) error {
+	var lenPrefix [4]byte
+	if _, err := io.ReadFull(r, lenPrefix[:]); err != nil {
+		return err
+	}
+	buf := make([]byte, binary.BigEndian.Uint32(lenPrefix[:]))
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return err
+	}
+	return json.Unmarshal(buf, v)
+}
+
+// GobValue tags an argument that didn't round-trip as a JSON primitive and
+// was instead gob-encoded, since JSON alone can't carry arbitrary Go types
+// (structs, slices of structs, etc.) across the wire without a schema. gob
+// requires the concrete type to be registered with gob.Register before it
+// can be encoded behind an interface{}, so this only works for types the
+// plugin (or host) has registered; ErrorValue below covers the common case
+// of a plain error without asking callers to register anything.
+type GobValue struct {
+	Gob []byte `json:"gob"`
+}
+
+// ErrorValue tags an error argument or result: errors are this protocol's
+// single most common non-primitive value (see the error-as-last-result
+// convention both sides use), and their concrete types are typically
+// unexported (*errors.errorString, *fmt.wrapError, ...) so they can't be
+// gob.Register-ed by the caller. Carrying just the message sidesteps gob
+// for them entirely, at the cost of losing anything but errors.New(msg) on
+// the other side.
+type ErrorValue struct {
+	Err string `json:"err"`
+}
+
+// MarshalArgs prepares values for the wire: primitives pass through as-is
+// for plain JSON encoding, errors go through ErrorValue, and anything else
+// is gob-encoded and tagged so the other side knows to gob-decode it (see
+// GobValue; the concrete type must already be gob.Register-ed).
+func MarshalArgs(args []interface{}) ([]interface{}, error) {
+	out := make([]interface{}, len(args))
+	for i, a := range args {
+		if IsJSONPrimitive(a) {
+			out[i] = a
+			continue
+		}
+		if e, ok := a.(error); ok {
+			out[i] = ErrorValue{Err: e.Error()}
+			continue
+		}
+		var buf bytes.Buffer
+		if err := gob.NewEncoder(&buf).Encode(&a); err != nil {
+			return nil, fmt.Errorf("wireproto: gob-encode arg %d: %w (non-primitive, non-error types must be registered with gob.Register)", i, err)
+		}
+		out[i] = GobValue{Gob: buf.Bytes()}
+	}
+	return out, nil
+}
+
+// UnmarshalArgs is MarshalArgs' inverse for values coming back off the
+// wire; error- and gob-tagged results are decoded back into an
+// interface{}, everything else is returned as whatever json.Unmarshal
+// produced for it.
+func UnmarshalArgs(vals []interface{}) []interface{} {
+	out := make([]interface{}, len(vals))
+	for i, v := range vals {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			out[i] = v
+			continue
+		}
+		if rawErr, ok := m["err"]; ok {
+			msg, _ := rawErr.(string)
+			out[i] = errors.New(msg)
+			continue
+		}
+		raw, ok := m["gob"]
+		if !ok {
+			out[i] = v
+			continue
+		}
+		encoded, _ := raw.(string)
+		b, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			out[i] = v
+			continue
+		}
+		var decoded interface{}
+		if err := gob.NewDecoder(bytes.NewReader(b)).Decode(&decoded); err == nil {
+			out[i] = decoded
+		} else {
+			out[i] = v
+		}
+	}
+	return out
+}
+
+// IsJSONPrimitive reports whether v can be carried on the wire as plain
+// JSON without MarshalArgs' GobValue/ErrorValue wrapping.
+func IsJSONPrimitive(v interface{}) bool {
+	switch v.(type) {
+	case nil, bool, string,
+		int, int8, int16, int32, int64,
+		uint, uint8, uint16, uint32, uint64,
+		float32, float64:
+		return true
+	default:
+		return false
+	}
+}
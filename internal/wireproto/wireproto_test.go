@@ -0,0 +1,67 @@
+package wireproto
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+// TestWriteReadFrameRoundTrip verifies ReadFrame decodes exactly what
+// WriteFrame encoded, length prefix and all.
+func TestWriteReadFrameRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	want := Request{Op: "call", Fn: "DoWork", Args: []interface{}{"a", float64(1)}}
+	if err := WriteFrame(&buf, want); err != nil {
+		t.Fatalf("WriteFrame: %v", err)
+	}
+	var got Request
+	if err := ReadFrame(bufio.NewReader(&buf), &got); err != nil {
+		t.Fatalf("ReadFrame: %v", err)
+	}
+	if got.Op != want.Op || got.Fn != want.Fn || len(got.Args) != len(want.Args) {
+		t.Fatalf("got = %+v, want %+v", got, want)
+	}
+}
+
+type point struct{ X, Y int }
+
+// TestMarshalArgsRoundTrip verifies MarshalArgs/UnmarshalArgs carry JSON
+// primitives, errors, and gob-registered structs across a JSON-encode/decode
+// cycle unchanged, the three argument shapes execTransport and
+// plugin_manager/rpc actually need to support.
+func TestMarshalArgsRoundTrip(t *testing.T) {
+	gob.Register(point{})
+	args := []interface{}{"s", 42.0, true, nil, errors.New("boom"), point{X: 1, Y: 2}}
+	wire, err := MarshalArgs(args)
+	if err != nil {
+		t.Fatalf("MarshalArgs: %v", err)
+	}
+
+	// Simulate the wire: JSON-encode then decode back into interface{},
+	// the same transformation a real frame goes through.
+	b, err := json.Marshal(wire)
+	if err != nil {
+		t.Fatalf("json.Marshal: %v", err)
+	}
+	var decoded []interface{}
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		t.Fatalf("json.Unmarshal: %v", err)
+	}
+
+	out := UnmarshalArgs(decoded)
+	if len(out) != len(args) {
+		t.Fatalf("len(out) = %d, want %d", len(out), len(args))
+	}
+	if out[0] != "s" || out[1] != 42.0 || out[2] != true || out[3] != nil {
+		t.Fatalf("primitives didn't round-trip: %v", out)
+	}
+	if e, ok := out[4].(error); !ok || e.Error() != "boom" {
+		t.Fatalf("out[4] = %v, want an error \"boom\"", out[4])
+	}
+	if p, ok := out[5].(point); !ok || p != (point{X: 1, Y: 2}) {
+		t.Fatalf("out[5] = %v, want point{1,2}", out[5])
+	}
+}
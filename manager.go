@@ -0,0 +1,242 @@
+package plugin_manager
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// ManagerOptions configures a Manager created by NewManager.
+type ManagerOptions struct {
+	// UnloadDrainTimeout bounds how long Unload waits for in-flight Call
+	// invocations to finish before giving up. Zero (the default) means
+	// wait indefinitely.
+	UnloadDrainTimeout time.Duration
+	// CallTimeout is the default per-call timeout new plugins get (see
+	// Plugin.Call). Zero means use PluginTimeout.
+	CallTimeout time.Duration
+	// Transport controls how plugins are opened and their symbols resolved.
+	// Nil picks dlopenTransport where cgo-backed plugin loading is
+	// available and execTransport elsewhere.
+	Transport Transport
+	// WatchDirs are directories Run watches for plugin binaries being
+	// created or rewritten, hot-reloading the corresponding Plugin on
+	// change (see Manager.WatchDir). Nil disables watching at startup;
+	// WatchDir can still be called directly afterward.
+	WatchDirs []string
+	// ReloadDebounce coalesces filesystem events for the same path that
+	// arrive within this window into a single reload, so editors that
+	// write-then-rename a binary don't trigger two. Zero means 500ms.
+	ReloadDebounce time.Duration
+	// Events, if non-nil, receives a PluginEvent for every Load/Reload
+	// triggered by a watched directory, so callers can rebuild anything
+	// (e.g. a routing table) that caches GetFunc results. The manager never
+	// blocks on a full channel; it logs and drops the event instead.
+	Events chan PluginEvent
+}
+
+// Manager loads plugins and dispatches calls into them by name.
+type Manager interface {
+	// Run starts the manager, making it ready to serve GetPlugin/GetFunc.
+	Run() error
+	// IsRunning reports whether Run has been called successfully.
+	IsRunning() bool
+	// GetPlugin returns the most recently loaded plugin registered under name.
+	GetPlugin(name string) (*Plugin, error)
+	// GetPluginWithVersion returns the plugin registered under name with the
+	// given version, if any.
+	GetPluginWithVersion(name string, version uint64) (*Plugin, error)
+	// GetPluginByPkgPath returns the plugin registered under (name, version,
+	// pkgPath), distinguishing plugins that share a name and version but
+	// were built from different packages.
+	GetPluginByPkgPath(name string, version uint64, pkgPath string) (*Plugin, error)
+	// GetFunc looks up module and returns a callable for function on it.
+	GetFunc(module, function string) (func(...interface{}) []interface{}, error)
+	// OnLoaded is called by Plugin.Load once a plugin has registered itself.
+	OnLoaded(p *Plugin)
+	// UnloadDrainTimeout returns how long Unload should wait for in-flight
+	// calls to drain before giving up, or 0 to wait indefinitely.
+	UnloadDrainTimeout() time.Duration
+	// CallTimeout returns the default per-call timeout new plugins get, or
+	// 0 to fall back to PluginTimeout.
+	CallTimeout() time.Duration
+	// InFlight returns the total number of Call/CallContext invocations
+	// currently executing across all plugins this manager has loaded,
+	// including any left running past a CallContext timeout.
+	InFlight() int32
+	// Transport returns the Transport new plugins should use to load.
+	Transport() Transport
+	// WatchDir monitors dir for plugin binaries being created or rewritten,
+	// hot-reloading the corresponding Plugin on change. It can be called
+	// any number of times, including before Run.
+	WatchDir(dir string) error
+	// Events returns the channel PluginEvents are published to, or nil if
+	// ManagerOptions.Events wasn't set.
+	Events() <-chan PluginEvent
+	// FailedPlugin returns the Plugin from the most recent failed
+	// watch-driven Load/Reload at path, or nil if its last attempt
+	// succeeded or path was never watched. Check its LoadError for why.
+	FailedPlugin(path string) *Plugin
+	// Close stops the manager: pending debounced reloads are canceled and,
+	// if WatchDir was ever called, the underlying filesystem watcher is
+	// torn down and watchLoop exits. It does not Unload any loaded plugin.
+	Close() error
+}
+
+// pluginKey disambiguates plugins the way the Go linker disambiguates
+// exported symbols across plugins: by name, version, and import path.
+type pluginKey struct {
+	name    string
+	version uint64
+	pkgPath string
+}
+
+type manager struct {
+	sync.RWMutex
+	options ManagerOptions
+	running bool
+	plugins map[string]*Plugin    // name -> most recently loaded plugin
+	byKey   map[pluginKey]*Plugin // (name, version, pkgPath) -> plugin
+	byPath  map[string]*Plugin    // path -> most recently loaded plugin; see WatchDir
+
+	watcher      *fsnotify.Watcher
+	watchOnce    sync.Once
+	watchInitErr error
+	pending      map[string]*time.Timer // path -> pending debounced reload
+	failedByPath map[string]*Plugin     // path -> last failed watch-driven Load/Reload; see watcher.go
+}
+
+// NewManager creates a Manager from options. Call Run to start it.
+func NewManager(options ManagerOptions) (Manager, error) {
+	return &manager{
+		options:      options,
+		plugins:      make(map[string]*Plugin),
+		byKey:        make(map[pluginKey]*Plugin),
+		byPath:       make(map[string]*Plugin),
+		failedByPath: make(map[string]*Plugin),
+	}, nil
+}
+
+func (m *manager) Run() error {
+	m.Lock()
+	m.running = true
+	m.Unlock()
+	for _, dir := range m.options.WatchDirs {
+		if err := m.WatchDir(dir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *manager) IsRunning() bool {
+	m.RLock()
+	defer m.RUnlock()
+	return m.running
+}
+
+func (m *manager) Close() error {
+	m.Lock()
+	m.running = false
+	for path, t := range m.pending {
+		t.Stop()
+		delete(m.pending, path)
+	}
+	w := m.watcher
+	m.Unlock()
+	if w == nil {
+		return nil
+	}
+	// Closing the fsnotify.Watcher closes its Events/Errors channels,
+	// which is what makes watchLoop's select return.
+	return w.Close()
+}
+
+func (m *manager) GetPlugin(name string) (*Plugin, error) {
+	m.RLock()
+	defer m.RUnlock()
+	p, ok := m.plugins[name]
+	if !ok {
+		return nil, fmt.Errorf("plugin_manager: plugin %q not found", name)
+	}
+	return p, nil
+}
+
+func (m *manager) GetPluginWithVersion(name string, version uint64) (*Plugin, error) {
+	p, err := m.GetPlugin(name)
+	if err != nil {
+		return nil, err
+	}
+	if p.Version() != version {
+		return nil, fmt.Errorf("plugin_manager: plugin %q version 0x%x not found", name, version)
+	}
+	return p, nil
+}
+
+func (m *manager) GetPluginByPkgPath(name string, version uint64, pkgPath string) (*Plugin, error) {
+	m.RLock()
+	defer m.RUnlock()
+	p, ok := m.byKey[pluginKey{name: name, version: version, pkgPath: pkgPath}]
+	if !ok {
+		return nil, fmt.Errorf("plugin_manager: plugin %q version 0x%x pkgPath %q not found", name, version, pkgPath)
+	}
+	return p, nil
+}
+
+func (m *manager) GetFunc(module, function string) (func(...interface{}) []interface{}, error) {
+	p, err := m.GetPlugin(module)
+	if err != nil {
+		return nil, err
+	}
+	return p.GetFunc(function)
+}
+
+func (m *manager) OnLoaded(p *Plugin) {
+	m.Lock()
+	defer m.Unlock()
+	if old := p.replaces; old != nil {
+		// old's identity fields are immutable by the time we get here: its
+		// own Load finished (and OnLoaded ran) before reloadPath ever set
+		// p.replaces = old, and reloadPath doesn't call old.Unload until
+		// this call returns. A version bump means old's pluginKey differs
+		// from p's, so drop it or it'd keep resolving to a plugin that's
+		// about to be torn down forever.
+		oldKey := pluginKey{name: old.name, version: old.version, pkgPath: old.pkgPath}
+		newKey := pluginKey{name: p.Name(), version: p.Version(), pkgPath: p.pkgPath}
+		if oldKey != newKey {
+			delete(m.byKey, oldKey)
+		}
+	}
+	m.plugins[p.Name()] = p
+	// p is already locked by the in-flight Load that's calling us, so read
+	// pkgPath directly rather than through the locking PkgPath accessor.
+	m.byKey[pluginKey{name: p.Name(), version: p.Version(), pkgPath: p.pkgPath}] = p
+	m.byPath[p.path] = p
+}
+
+func (m *manager) UnloadDrainTimeout() time.Duration {
+	return m.options.UnloadDrainTimeout
+}
+
+func (m *manager) CallTimeout() time.Duration {
+	return m.options.CallTimeout
+}
+
+func (m *manager) InFlight() int32 {
+	m.RLock()
+	defer m.RUnlock()
+	var total int32
+	for _, p := range m.byKey {
+		total += p.InFlight()
+	}
+	return total
+}
+
+func (m *manager) Transport() Transport {
+	if m.options.Transport != nil {
+		return m.options.Transport
+	}
+	return defaultTransport()
+}
@@ -1,10 +1,13 @@
 package plugin_manager
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -21,7 +24,15 @@ const (
 	PluginStatusReloading
 	PluginStatusUnloading
 	PluginStatusUnloaded
+	// PluginStatusFailed means the last Load attempt failed. It is distinct
+	// from PluginStatusNone ("never tried") so operators can tell a plugin
+	// that is known bad apart from one that simply hasn't been loaded yet.
+	// Load alone never clears it. Unload resets it to PluginStatusNone;
+	// Reload does that and also drops Open's cached failure for the path
+	// before loading again.
+	PluginStatusFailed
 )
+
 type Symbol any
 
 type PluginType struct {
@@ -29,21 +40,67 @@ type PluginType struct {
 	err        string        // set if plugin failed to load
 	loaded     chan struct{} // closed when loaded
 	syms       map[string]any
+	// native holds the platform-specific handle open built this PluginType
+	// from (a *plugin.Plugin on the cgo-enabled build; see plugin_dlopen.go
+	// and its no-cgo counterpart plugin_stubs.go).
+	native any
 }
 
-// Open opens a Go plugin.
-// If a path has already been opened, then the existing *Plugin is returned.
-// It is safe for concurrent use by multiple goroutines.
+// openPlugins caches every path ever passed to Open, success or failure, the
+// same way the upstream plugin package caches dlopen'd plugins by path. A
+// path that previously failed (bad Go build ID, missing symbol, a Lookup
+// that came back empty) stays "bad" in this map so a caller that keeps
+// calling Open on it — or a filesystem watcher reacting to repeated churn
+// on a broken rebuild — doesn't keep re-paying the dlopen cost. Plugin.Load
+// layers its own PluginStatusFailed on top of this for a *Plugin that's
+// reused across calls; this cache is what protects a throwaway one.
+var (
+	openPluginsMu sync.Mutex
+	openPlugins   = make(map[string]*PluginType)
+)
+
+// Open opens a Go plugin, invoking dlopen (or the platform's equivalent).
+// If path previously failed to open or resolve a symbol, Open returns the
+// cached failure without re-invoking dlopen; call Reload on whatever Plugin
+// wraps path to clear it and retry. It is safe for concurrent use by
+// multiple goroutines.
 func Open(path string) (*PluginType, error) {
-	return open(path)
+	openPluginsMu.Lock()
+	if p, ok := openPlugins[path]; ok && p.err != "" {
+		openPluginsMu.Unlock()
+		return nil, errors.New("plugin.Open(\"" + path + "\"): " + p.err + " (previous failure)")
+	}
+	openPluginsMu.Unlock()
+
+	p, err := open(path)
+	if err != nil {
+		openPluginsMu.Lock()
+		openPlugins[path] = &PluginType{pluginpath: path, err: err.Error()}
+		openPluginsMu.Unlock()
+		return nil, err
+	}
+	openPluginsMu.Lock()
+	openPlugins[path] = p
+	openPluginsMu.Unlock()
+	return p, nil
 }
 
 // Lookup searches for a symbol named symName in plugin p.
 // A symbol is any exported variable or function.
 // It reports an error if the symbol is not found.
 // It is safe for concurrent use by multiple goroutines.
+//
+// A failed Lookup marks p bad the same way a failed Open does, so the next
+// Open(p.pluginpath) returns the cached failure instead of re-opening path
+// just to fail the same Lookup again.
 func (p *PluginType) Lookup(symName string) (Symbol, error) {
-	return lookup(p, symName)
+	s, err := lookup(p, symName)
+	if err != nil {
+		openPluginsMu.Lock()
+		p.err = err.Error()
+		openPluginsMu.Unlock()
+	}
+	return s, err
 }
 
 type PluginError struct {
@@ -62,24 +119,47 @@ type pluginFuncInfo struct {
 
 type Plugin struct {
 	sync.RWMutex
-	m       Manager
-	name    string
-	version uint64
-	path    string
-	plugin  *PluginType
-	status  PluginStatus
-	refs    int
-	cache   map[string]*pluginFuncInfo
+	m         Manager
+	name      string
+	version   uint64
+	path      string
+	pkgPath   string // import path symbols are prefixed with; see GetFunc
+	transport Transport
+	handle    PluginHandle
+	status    PluginStatus
+	loadErr   error // set when status is PluginStatusFailed
+	refs      int32 // in-flight Call/GetFunc-returned-closure invocations
+	refsCond  *sync.Cond
+	timeout   time.Duration // default Call timeout; see callTimeout
+	cache     map[string]*pluginFuncInfo
+	// replaces is set by the watch subsystem (see WatchDir) before Load is
+	// called on a plugin that's hot-reloading another one in place. Without
+	// it, registerLoaded's double-load guard would reject the replacement
+	// since the old plugin is deliberately kept registered and serving
+	// traffic until the new one has finished loading.
+	replaces *Plugin
 }
 
+// ErrPluginUnloading is returned by GetFunc and Call from the moment Unload
+// begins draining in-flight calls until the plugin is loaded again by a
+// later Load or Reload.
+var ErrPluginUnloading = errors.New("plugin_manager: plugin is unloading")
+
 func NewPlugin(path string, m Manager) *Plugin {
 	p := &Plugin{
 		m:      m,
 		path:   path,
 		status: PluginStatusNone,
-		refs:   0,
 		cache:  make(map[string]*pluginFuncInfo),
 	}
+	if m != nil {
+		p.timeout = m.CallTimeout()
+		p.transport = m.Transport()
+	}
+	if p.transport == nil {
+		p.transport = defaultTransport()
+	}
+	p.refsCond = sync.NewCond(&p.RWMutex)
 	return p
 }
 
@@ -87,6 +167,13 @@ func (p *Plugin) Status() PluginStatus {
 	return PluginStatus(atomic.LoadInt32((*int32)(&(p.status))))
 }
 
+// InFlight reports the number of Call/GetFunc-returned-closure invocations
+// currently executing inside the plugin, including any left running past a
+// CallContext timeout (see CallContext).
+func (p *Plugin) InFlight() int32 {
+	return atomic.LoadInt32(&p.refs)
+}
+
 func (p *Plugin) setStatus(status PluginStatus) {
 	atomic.StoreInt32((*int32)(&(p.status)), int32(status))
 }
@@ -103,46 +190,152 @@ func (p *Plugin) Path() string {
 	return p.path
 }
 
+// PkgPath returns the import path GetFunc prefixes symbol lookups with, so
+// that plugins built from different packages can export the same function
+// name without colliding. It defaults to the basename of Path, minus its
+// extension, if the plugin's Load didn't supply one.
+func (p *Plugin) PkgPath() string {
+	p.RLock()
+	defer p.RUnlock()
+	return p.pkgPath
+}
+
+// defaultPkgPath derives a PkgPath for plugins built before register grew
+// its pkgPath argument, mirroring how the Go linker names a plugin after
+// its main package when nothing more specific is known.
+func defaultPkgPath(path string) string {
+	name := filepath.Base(path)
+	return strings.TrimSuffix(name, filepath.Ext(name))
+}
+
+// LoadError returns the error from the last failed Load, or nil if the
+// plugin has never failed to load (or a Reload has since cleared it).
+func (p *Plugin) LoadError() error {
+	p.RLock()
+	defer p.RUnlock()
+	return p.loadErr
+}
+
+func (p *Plugin) fail(e error) error {
+	p.loadErr = e
+	p.setStatus(PluginStatusFailed)
+	// Wake any Load/Unload waiting on this one to leave PluginStatusLoading
+	// (see Load's wait loop).
+	p.refsCond.Broadcast()
+	return e
+}
+
+// failAfterOpen is fail for the case where transport.Open already succeeded
+// before e occurred (a bad symbol lookup, a failing plugin Load hook, or
+// registerLoaded's double-load guard): it releases the transport handle
+// first so a failed Load doesn't leak whatever Open acquired, notably
+// execTransport's child process. p must be locked.
+func (p *Plugin) failAfterOpen(e error) error {
+	if p.handle != nil {
+		if closeErr := p.transport.Close(p.handle); closeErr != nil {
+			log.Print("plugin ", p.path, ": transport close after failed load: ", closeErr)
+		}
+		p.handle = nil
+	}
+	return p.fail(e)
+}
+
+// registerLoaded is the register callback passed to a plugin's Load symbol
+// (or, for self-registering transports such as execTransport, called
+// directly with the identity the plugin announced on its own).
+func (p *Plugin) registerLoaded(name string, version uint64, pkgPath string) error {
+	p.name = name
+	p.version = version
+	if pkgPath == "" {
+		pkgPath = defaultPkgPath(p.path)
+	}
+	p.pkgPath = pkgPath
+	s := fmt.Sprintf("load plugin: %s, version: 0x%x, pkgPath: %s", p.name, p.version, p.pkgPath)
+	p1, e1 := p.m.GetPluginByPkgPath(name, version, pkgPath)
+	if p1 != nil && p1 != p && p1 != p.replaces {
+		e1 = errors.New("can't double load plugin")
+		log.Println(s, ", error: ", e1.Error())
+		return p.failAfterOpen(e1)
+	}
+	log.Println(s)
+	p.setStatus(PluginStatusLoaded)
+	// Wake any Load/Unload waiting on this one to leave PluginStatusLoading.
+	p.refsCond.Broadcast()
+	p.m.OnLoaded(p)
+	return nil
+}
+
 func (p *Plugin) Load() error {
 	p.Lock()
-	defer p.Unlock()
+	// Loading/Unloading are transitional: wait for whichever Load/Unload is
+	// already in flight to finish and re-evaluate, instead of no-opping
+	// against a status that's about to change out from under us (see also
+	// Unload, which waits the same way).
+	for p.Status() == PluginStatusLoading || p.Status() == PluginStatusUnloading {
+		p.refsCond.Wait()
+	}
+	if p.Status() == PluginStatusFailed {
+		loadErr := p.loadErr
+		p.Unlock()
+		return fmt.Errorf("plugin %s: %w (previous failure, call Reload to retry)", p.path, loadErr)
+	}
 	if p.Status() != PluginStatusNone && p.Status() != PluginStatusUnloaded {
+		// Already loaded, possibly by the Load we were just waiting on.
+		p.Unlock()
 		return nil
 	}
 	p.setStatus(PluginStatusLoading)
 	path := p.path
-	p1, e := Open(path)
+	handle, e := p.transport.Open(path)
 	if e != nil {
 		log.Print("load plugin ", path, " error: ", e)
-		p.setStatus(PluginStatusNone)
-		return e
+		err := p.fail(e)
+		p.Unlock()
+		return err
+	}
+	p.handle = handle
+
+	// Transports that can't carry a host-side callback across a process
+	// boundary (execTransport) announce their identity directly instead of
+	// exporting a Load(register) symbol.
+	if sr, ok := handle.(SelfRegistering); ok {
+		name, version, pkgPath := sr.PluginIdentity()
+		defer p.Unlock()
+		return p.registerLoaded(name, version, pkgPath)
 	}
-	p.plugin = p1
-	f, e := p1.Lookup("Load")
+
+	info, e := p.lookupAndBuild("Load")
 	if e != nil {
 		log.Print("load plugin ", path, " error: ", e)
-		p.setStatus(PluginStatusNone)
-		return e
-	}
-	register := func(name string, version uint64) error {
-		p.name = name
-		p.version = version
-		s := fmt.Sprintf("load plugin: %s, version: 0x%x", p.name, p.version)
-		p1, e1 := p.m.GetPluginWithVersion(name, version)
-		if p1 != nil {
-			e1 = errors.New("can't double load plugin")
-			log.Println(s, ", error: ", e1.Error())
-			p.setStatus(PluginStatusNone)
-			return e1
-		} else {
-			log.Println(s)
-			p.setStatus(PluginStatusLoaded)
-			p.m.OnLoaded(p)
-			return nil
-		}
+		err := p.failAfterOpen(e)
+		p.Unlock()
+		return err
+	}
+	register, e := p.registerArgFor(info)
+	if e != nil {
+		log.Print("load plugin ", path, " error: ", e)
+		err := p.failAfterOpen(e)
+		p.Unlock()
+		return err
 	}
-	e = f.(func(func(string, uint64) error) error)(register)
+	p.Unlock()
 
+	// info.fn is called with p unlocked: its refcounted wrapper (see
+	// buildCallable) releases its ref by reacquiring p's lock when it
+	// returns, which would deadlock against Load still holding it.
+	out := info.fn(register)
+	if len(out) == 0 {
+		return nil
+	}
+	e, _ = out[len(out)-1].(error)
+	if e == nil {
+		return nil
+	}
+	p.Lock()
+	defer p.Unlock()
+	if p.Status() != PluginStatusFailed {
+		return p.failAfterOpen(e)
+	}
 	return e
 }
 
@@ -150,6 +343,12 @@ func (p *Plugin) Reload() error {
 	if err := p.Unload(); err != nil {
 		return err
 	}
+	// Drop Open's cached failure for this path too, so Load retries it.
+	openPluginsMu.Lock()
+	if pt, ok := openPlugins[p.path]; ok && pt.err != "" {
+		delete(openPlugins, p.path)
+	}
+	openPluginsMu.Unlock()
 	if err := p.Load(); err != nil {
 		return err
 	}
@@ -163,53 +362,226 @@ func (p *Plugin) Reload() error {
 	return nil
 }
 
-func (p *Plugin) Unload() error {
+// releaseRef is the deferred half of the refcounting in GetFunc's returned
+// closure. It wakes an Unload that's draining in-flight calls once the last
+// one finishes.
+func (p *Plugin) releaseRef() {
+	if atomic.AddInt32(&p.refs, -1) == 0 {
+		p.Lock()
+		p.refsCond.Broadcast()
+		p.Unlock()
+	}
+}
+
+// drainRefs blocks until no Call/GetFunc-returned closure is in flight, or
+// until timeout elapses (no deadline if timeout <= 0). p must not be locked
+// by the caller.
+func (p *Plugin) drainRefs(timeout time.Duration) error {
 	p.Lock()
 	defer p.Unlock()
+	if timeout <= 0 {
+		for atomic.LoadInt32(&p.refs) != 0 {
+			p.refsCond.Wait()
+		}
+		return nil
+	}
+	timedOut := false
+	timer := time.AfterFunc(timeout, func() {
+		p.Lock()
+		timedOut = true
+		p.refsCond.Broadcast()
+		p.Unlock()
+	})
+	defer timer.Stop()
+	for atomic.LoadInt32(&p.refs) != 0 {
+		if timedOut {
+			return fmt.Errorf("plugin_manager: timed out after %s waiting for %d in-flight call(s) to drain", timeout, atomic.LoadInt32(&p.refs))
+		}
+		p.refsCond.Wait()
+	}
+	return nil
+}
+
+func (p *Plugin) Unload() error {
+	p.Lock()
+	// See Load's wait loop: don't race a Load that's still in flight (or
+	// pile onto an Unload that's already draining) by re-evaluating status
+	// ourselves before it's settled.
+	for p.Status() == PluginStatusLoading || p.Status() == PluginStatusUnloading {
+		p.refsCond.Wait()
+	}
 	if p.Status() == PluginStatusUnloaded ||
 		p.Status() == PluginStatusUnloading ||
 		p.Status() == PluginStatusNone {
+		p.Unlock()
 		return nil
 	}
-	p.cache = make(map[string]*pluginFuncInfo)
+	if p.Status() == PluginStatusFailed {
+		// Nothing was ever successfully loaded; just clear the failure so
+		// Reload's subsequent Load can try again.
+		p.loadErr = nil
+		p.handle = nil
+		p.setStatus(PluginStatusNone)
+		p.Unlock()
+		return nil
+	}
+	// Mark unloading and release the lock before draining so GetFunc can
+	// observe the new status and fail fast with ErrPluginUnloading instead
+	// of blocking on p for the duration of the drain.
+	p.setStatus(PluginStatusUnloading)
 	name := p.name
 	version := p.version
+	handle := p.handle
+	p.Unlock()
+
+	if err := p.drainRefs(p.m.UnloadDrainTimeout()); err != nil {
+		// Give up on this Unload: the plugin stays loaded, and any Load or
+		// Unload waiting on PluginStatusUnloading re-evaluates.
+		p.Lock()
+		p.setStatus(PluginStatusLoaded)
+		p.refsCond.Broadcast()
+		p.Unlock()
+		return err
+	}
+
+	p.Lock()
+	p.cache = make(map[string]*pluginFuncInfo)
+	info, lookupErr := p.lookupAndBuild("Unload")
+	p.Unlock()
+
+	// info.fn is called with p unlocked: its refcounted wrapper (see
+	// buildCallable) releases its ref by reacquiring p's lock when it
+	// returns, which would deadlock against Unload still holding it.
 	s := fmt.Sprintf("unload plugin: %s, version: 0x%x", name, version)
-	f, e := p.plugin.Lookup("Unload")
-	if e != nil {
-		log.Print(s, ", error: ", e)
-		return e
+	var err error
+	if lookupErr != nil {
+		log.Print(s, ", error: ", lookupErr)
+		err = lookupErr
+	} else if out := info.fn(); len(out) > 0 {
+		err, _ = out[len(out)-1].(error)
+	}
+
+	p.Lock()
+	defer p.Unlock()
+	// Release the transport-level handle (e.g. reap the execTransport child
+	// process) regardless of whether the plugin's own Unload succeeded.
+	if closeErr := p.transport.Close(handle); closeErr != nil {
+		log.Print(s, ", transport close error: ", closeErr)
 	}
-	err := f.(func() error)()
 	log.Print(s)
 	p.setStatus(PluginStatusUnloaded)
+	// Wake any Load/Unload waiting on this one to leave PluginStatusUnloading.
+	p.refsCond.Broadcast()
 	return err
 }
 
+// callTimeout returns the default timeout CallContext is given PluginTimeout
+// for (see Call), preferring the manager-configured default over the
+// package-wide PluginTimeout.
+func (p *Plugin) callTimeout() time.Duration {
+	if p.timeout > 0 {
+		return p.timeout
+	}
+	return PluginTimeout
+}
+
+// Call invokes fun with params, bounding it to the plugin's default timeout
+// (PluginTimeout unless overridden via ManagerOptions or WithTimeout). On
+// timeout or cancellation it returns a single-element slice holding the
+// context error, matching the error-as-last-result convention GetFunc uses.
 func (p *Plugin) Call(fun string, params ...interface{}) []interface{} {
-	f, err := p.GetFunc(fun)
+	ctx, cancel := context.WithTimeout(context.Background(), p.callTimeout())
+	defer cancel()
+	out, err := p.CallContext(ctx, fun, params...)
 	if err != nil {
 		return []interface{}{err}
 	}
-	return f(params...)
+	return out
 }
 
-func (p *Plugin) GetFunc(fun string) (f func(...interface{}) []interface{}, err error) {
-	p.Lock()
-	defer p.Unlock()
-	if p.plugin == nil {
-		err = errors.New("plugin not loaded")
-		return
+// CallContext invokes fun with params and returns its results, or ctx.Err()
+// if ctx is done before the plugin call returns.
+//
+// Go plugin functions can't be preempted, so when ctx expires the goroutine
+// running the call keeps running until the plugin eventually returns; it
+// leaks for the remainder of that call. Manager.InFlight reports the number
+// of such in-flight (including leaked) calls so operators can notice a
+// plugin that's chronically overrunning its timeout.
+func (p *Plugin) CallContext(ctx context.Context, fun string, params ...interface{}) ([]interface{}, error) {
+	f, err := p.GetFunc(fun)
+	if err != nil {
+		return nil, err
 	}
-	info, ok := p.cache[fun]
-	if ok {
-		return info.fn, nil
+	resultCh := make(chan []interface{}, 1)
+	go func() {
+		resultCh <- f(params...)
+	}()
+	select {
+	case out := <-resultCh:
+		return out, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
 	}
-	f1, err := p.plugin.Lookup(fun)
+}
+
+// PluginCaller is a Plugin bound to a specific Call timeout, built with
+// Plugin.WithTimeout.
+type PluginCaller struct {
+	p       *Plugin
+	timeout time.Duration
+}
+
+// WithTimeout returns a PluginCaller that calls fun with a per-call timeout
+// of d instead of the plugin's default (see Plugin.Call).
+func (p *Plugin) WithTimeout(d time.Duration) *PluginCaller {
+	return &PluginCaller{p: p, timeout: d}
+}
+
+// Call invokes fun with params, bounding it to the timeout given to WithTimeout.
+func (c *PluginCaller) Call(fun string, params ...interface{}) []interface{} {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+	out, err := c.p.CallContext(ctx, fun, params...)
 	if err != nil {
-		return nil, err
+		return []interface{}{err}
+	}
+	return out
+}
+
+// rpcCallable is implemented by Symbols that resolve to an out-of-process
+// call rather than a native Go function value (execTransport), since there
+// is no reflect.Value to invoke.
+type rpcCallable interface {
+	call(args []interface{}) ([]interface{}, error)
+}
+
+// SelfRegistering is implemented by a Transport's PluginHandle when the
+// plugin announces its own name/version/pkgPath instead of exporting a
+// Load(register) symbol, because the transport can't carry a host-side
+// callback across a process boundary (see execTransport).
+type SelfRegistering interface {
+	PluginIdentity() (name string, version uint64, pkgPath string)
+}
+
+// buildCallable wraps a looked-up Symbol in the uniform
+// func(...interface{}) []interface{} shim Call/GetFunc/Load/Unload all use,
+// whether the Symbol is a native Go function value (dlopenTransport) or an
+// RPC-backed one (execTransport).
+func (p *Plugin) buildCallable(f1 Symbol) (*pluginFuncInfo, error) {
+	if rc, ok := f1.(rpcCallable); ok {
+		info := &pluginFuncInfo{}
+		info.fn = func(params ...interface{}) []interface{} {
+			atomic.AddInt32(&p.refs, 1)
+			defer p.releaseRef()
+			out, err := rc.call(params)
+			if err != nil {
+				return []interface{}{err}
+			}
+			return out
+		}
+		return info, nil
 	}
-	info = &pluginFuncInfo{}
+	info := &pluginFuncInfo{}
 	info.rfv = reflect.ValueOf(f1)
 	info.rft = reflect.TypeOf(f1)
 	li := info.rfv.Type().NumIn()
@@ -222,7 +594,7 @@ func (p *Plugin) GetFunc(fun string) (f func(...interface{}) []interface{}, err
 	for i := 0; i < lo; i++ {
 		info.outTypes[i] = info.rfv.Type().Out(i)
 	}
-	f = func(params ...interface{}) []interface{} {
+	info.fn = func(params ...interface{}) []interface{} {
 		out := make([]interface{}, len(info.outTypes))
 		if len(params) != len(info.inTypes) {
 			err := errors.New("The number of params is not adapted.")
@@ -241,6 +613,8 @@ func (p *Plugin) GetFunc(fun string) (f func(...interface{}) []interface{}, err
 				return out
 			}
 		}
+		atomic.AddInt32(&p.refs, 1)
+		defer p.releaseRef()
 		result := info.rfv.Call(in)
 		for i := 0; i < len(result); i++ {
 			out[i] = result[i].Interface()
@@ -248,7 +622,88 @@ func (p *Plugin) GetFunc(fun string) (f func(...interface{}) []interface{}, err
 
 		return out
 	}
-	info.fn = f
+	return info, nil
+}
+
+// lookupAndBuild resolves a fixed-signature lifecycle symbol (Load, Unload)
+// on the plugin's handle and wraps it via buildCallable. p must be locked.
+func (p *Plugin) lookupAndBuild(name string) (*pluginFuncInfo, error) {
+	f1, err := p.transport.Lookup(p.handle, name)
+	if err != nil {
+		return nil, err
+	}
+	return p.buildCallable(f1)
+}
+
+// registerType3 and registerType2 are the two Load(register ...) error
+// signatures plugin_manager has ever exported: the current one, taking a
+// pkgPath, and the one plugins built before pkgPath existed still use.
+var (
+	registerType3 = reflect.TypeOf((func(string, uint64, string) error)(nil))
+	registerType2 = reflect.TypeOf((func(string, uint64) error)(nil))
+)
+
+// registerArgFor builds the register callback to pass into a plugin's Load,
+// matching whichever of registerType3/registerType2 the looked-up Load
+// symbol actually declares, so a plugin built before pkgPath existed still
+// loads instead of Load's reflect.Call panicking on an arity mismatch. info
+// must describe the plugin's Load symbol (see lookupAndBuild).
+func (p *Plugin) registerArgFor(info *pluginFuncInfo) (interface{}, error) {
+	if len(info.inTypes) != 1 {
+		return nil, fmt.Errorf("plugin %s: Load takes %d arguments, want 1 (register)", p.path, len(info.inTypes))
+	}
+	register3 := func(name string, version uint64, pkgPath string) error {
+		p.Lock()
+		defer p.Unlock()
+		return p.registerLoaded(name, version, pkgPath)
+	}
+	switch info.inTypes[0] {
+	case registerType3:
+		return register3, nil
+	case registerType2:
+		// pkgPath defaults to "" the same way a 3-arg register called
+		// without one would; registerLoaded falls back to defaultPkgPath.
+		return func(name string, version uint64) error {
+			return register3(name, version, "")
+		}, nil
+	default:
+		return nil, fmt.Errorf("plugin %s: Load's register has unsupported signature %s", p.path, info.inTypes[0])
+	}
+}
+
+func (p *Plugin) GetFunc(fun string) (f func(...interface{}) []interface{}, err error) {
+	p.Lock()
+	defer p.Unlock()
+	if p.Status() == PluginStatusUnloading || p.Status() == PluginStatusUnloaded {
+		err = ErrPluginUnloading
+		return
+	}
+	if p.handle == nil {
+		err = errors.New("plugin not loaded")
+		return
+	}
+	info, ok := p.cache[fun]
+	if ok {
+		return info.fn, nil
+	}
+	// Recent Go linkers prefix exported plugin symbols with the plugin's
+	// import path, so that two plugins exporting the same function name
+	// don't collide. Try the prefixed name first and fall back to the bare
+	// name for plugins built before PkgPath existed.
+	var f1 Symbol
+	if p.pkgPath != "" {
+		f1, err = p.transport.Lookup(p.handle, p.pkgPath+"."+fun)
+	}
+	if f1 == nil {
+		f1, err = p.transport.Lookup(p.handle, fun)
+	}
+	if err != nil {
+		return nil, err
+	}
+	info, err = p.buildCallable(f1)
+	if err != nil {
+		return nil, err
+	}
 	p.cache[fun] = info
-	return f, nil
-}
\ No newline at end of file
+	return info.fn, nil
+}
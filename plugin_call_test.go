@@ -0,0 +1,85 @@
+package plugin_manager
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestCallContextTimeoutReturnsWhilePluginFuncKeepsRunning verifies
+// CallContext returns ctx.Err() as soon as ctx expires, even though the Go
+// plugin function it called can't be preempted and keeps running; see
+// CallContext's doc comment and Plugin.InFlight.
+func TestCallContextTimeoutReturnsWhilePluginFuncKeepsRunning(t *testing.T) {
+	release := make(chan struct{})
+	entered := make(chan struct{}, 1)
+	handle := &fakeHandle{syms: map[string]Symbol{
+		"Load":   loadRegister("slow", 1, "test/slow"),
+		"Unload": func() error { return nil },
+		"Slow": func() []interface{} {
+			entered <- struct{}{}
+			<-release
+			return []interface{}{nil}
+		},
+	}}
+	m := newTestManager(t, &fakeTransport{handle: handle})
+	p := NewPlugin("slow.so", m)
+	if err := p.Load(); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := p.CallContext(ctx, "Slow")
+	elapsed := time.Since(start)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed > 200*time.Millisecond {
+		t.Fatalf("CallContext took %s, want it to return promptly on timeout", elapsed)
+	}
+
+	select {
+	case <-entered:
+	case <-time.After(time.Second):
+		t.Fatal("Slow never started")
+	}
+	if n := p.InFlight(); n != 1 {
+		t.Fatalf("InFlight = %d, want 1 (Slow is still running past its timeout)", n)
+	}
+
+	close(release)
+	deadline := time.Now().Add(time.Second)
+	for p.InFlight() != 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if n := p.InFlight(); n != 0 {
+		t.Fatalf("InFlight = %d, want 0 once Slow returns", n)
+	}
+}
+
+// TestCallUsesPerCallTimeoutOverride verifies WithTimeout bounds Call to its
+// own timeout instead of the plugin's default.
+func TestCallUsesPerCallTimeoutOverride(t *testing.T) {
+	handle := &fakeHandle{syms: map[string]Symbol{
+		"Load":   loadRegister("slow", 1, "test/slow"),
+		"Unload": func() error { return nil },
+		"Fast": func() (string, error) {
+			time.Sleep(10 * time.Millisecond)
+			return "ok", nil
+		},
+	}}
+	m := newTestManager(t, &fakeTransport{handle: handle})
+	p := NewPlugin("slow.so", m)
+	p.timeout = time.Millisecond // default Call would time out well before Fast returns
+	if err := p.Load(); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	out := p.WithTimeout(time.Second).Call("Fast")
+	if len(out) != 2 || out[0] != "ok" || out[1] != nil {
+		t.Fatalf("out = %v, want [\"ok\", nil]", out)
+	}
+}
@@ -0,0 +1,32 @@
+//go:build (linux || freebsd || darwin) && cgo
+
+package plugin_manager
+
+import (
+	"errors"
+	goplugin "plugin"
+)
+
+// dlopenAvailable is true here because dlopen-backed Go plugins work on
+// Linux, macOS, and FreeBSD when cgo is enabled (the default wherever a C
+// toolchain is present); see plugin_stubs.go's build tag for the
+// complement, which covers every platform/build where it isn't.
+const dlopenAvailable = true
+
+// open wraps the standard library's plugin.Open, stashing the resulting
+// *plugin.Plugin in PluginType.native for lookup to use.
+func open(name string) (*PluginType, error) {
+	rp, err := goplugin.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &PluginType{pluginpath: name, native: rp}, nil
+}
+
+func lookup(p *PluginType, symName string) (Symbol, error) {
+	rp, ok := p.native.(*goplugin.Plugin)
+	if !ok {
+		return nil, errors.New("plugin_manager: not a dlopen-backed PluginType")
+	}
+	return rp.Lookup(symName)
+}
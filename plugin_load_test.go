@@ -0,0 +1,59 @@
+package plugin_manager
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestLoadFailureSetsStatusFailedAndReloadClears verifies a failed Load
+// leaves the plugin in PluginStatusFailed (distinct from PluginStatusNone)
+// and short-circuits further Load calls until Reload, which both clears the
+// failure and retries.
+func TestLoadFailureSetsStatusFailedAndReloadClears(t *testing.T) {
+	transport := &fakeTransport{openErr: errOpenBroken}
+	m := newTestManager(t, transport)
+	p := NewPlugin("broken.so", m)
+
+	if err := p.Load(); err == nil {
+		t.Fatal("Load: want error for a broken transport")
+	}
+	if status := p.Status(); status != PluginStatusFailed {
+		t.Fatalf("status = %v, want PluginStatusFailed", status)
+	}
+	if err := p.Load(); err == nil || !strings.Contains(err.Error(), "previous failure") {
+		t.Fatalf("second Load = %v, want it to short-circuit with the cached failure", err)
+	}
+
+	transport.openErr = nil
+	transport.handle = &fakeHandle{syms: map[string]Symbol{
+		"Load":   loadRegister("fixed", 1, "test/fixed"),
+		"Unload": func() error { return nil },
+	}}
+	if err := p.Reload(); err != nil {
+		t.Fatalf("Reload after fixing the transport: %v", err)
+	}
+	if status := p.Status(); status != PluginStatusLoaded {
+		t.Fatalf("status = %v, want PluginStatusLoaded", status)
+	}
+	if err := p.LoadError(); err != nil {
+		t.Fatalf("LoadError = %v, want nil once Reload succeeds", err)
+	}
+}
+
+// TestOpenCachesFailure verifies Open itself (the PluginType layer, not just
+// Plugin.Load's status tracking) caches a failed dlopen by path so repeated
+// Open calls on a known-bad path don't keep re-invoking dlopen.
+func TestOpenCachesFailure(t *testing.T) {
+	const path = "/nonexistent/plugin_manager_test/does-not-exist.so"
+	_, err1 := Open(path)
+	if err1 == nil {
+		t.Fatal("Open: want error for a path that doesn't exist")
+	}
+	_, err2 := Open(path)
+	if err2 == nil || !strings.Contains(err2.Error(), "previous failure") {
+		t.Fatalf("second Open = %v, want the cached previous failure", err2)
+	}
+}
+
+var errOpenBroken = errors.New("fakeTransport: simulated Open failure")
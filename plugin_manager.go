@@ -1,5 +1,7 @@
 package plugin_manager
 
+import "context"
+
 var defaultManager Manager
 
 func StartManager(options ManagerOptions) error {
@@ -23,6 +25,17 @@ func Call(module, function string, args ...interface{}) []interface{} {
 	return f(args...)
 }
 
+// CallContext is like Call but bounds the call to ctx, returning ctx.Err()
+// if ctx is done first. Call itself applies no timeout; Plugin.Call is the
+// variant bounded by the plugin's default timeout.
+func CallContext(ctx context.Context, module, function string, args ...interface{}) ([]interface{}, error) {
+	p, err := defaultManager.GetPlugin(module)
+	if err != nil {
+		return nil, err
+	}
+	return p.CallContext(ctx, function, args...)
+}
+
 func GetPlugin(name string) (*Plugin, error) {
 	return defaultManager.GetPlugin(name)
 }
@@ -33,4 +46,4 @@ func GetPluginWithVersion(name string, version uint64) (*Plugin, error) {
 
 func GetFunc(module, function string) (f func(...interface{}) []interface{}, err error) {
 	return defaultManager.GetFunc(module, function)
-}
\ No newline at end of file
+}
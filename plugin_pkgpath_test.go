@@ -0,0 +1,71 @@
+package plugin_manager
+
+import "testing"
+
+// TestGetFuncPrefersPkgPathPrefixedSymbol verifies GetFunc tries
+// pkgPath+"."+fun before falling back to the bare name, so two plugins
+// built from different packages can export the same function name without
+// colliding (see PkgPath).
+func TestGetFuncPrefersPkgPathPrefixedSymbol(t *testing.T) {
+	handle := &fakeHandle{syms: map[string]Symbol{
+		"Load":                 loadRegister("dup", 1, "example.com/a"),
+		"Unload":               func() error { return nil },
+		"example.com/a.DoWork": func() string { return "prefixed" },
+		"DoWork":               func() string { return "bare" },
+	}}
+	m := newTestManager(t, &fakeTransport{handle: handle})
+	p := NewPlugin("a.so", m)
+	if err := p.Load(); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	f, err := p.GetFunc("DoWork")
+	if err != nil {
+		t.Fatalf("GetFunc: %v", err)
+	}
+	out := f()
+	if len(out) != 1 || out[0] != "prefixed" {
+		t.Fatalf("out = %v, want [\"prefixed\"] (the pkgPath-prefixed symbol)", out)
+	}
+}
+
+// TestManagerIndexesByPkgPath verifies the manager indexes plugins by
+// (name, version, pkgPath), so two plugins sharing a name and version but
+// built from different import paths can coexist, mirroring how the Go
+// linker disambiguates symbols across plugins.
+func TestManagerIndexesByPkgPath(t *testing.T) {
+	handleA := &fakeHandle{syms: map[string]Symbol{
+		"Load":   loadRegister("dup", 1, "example.com/a"),
+		"Unload": func() error { return nil },
+	}}
+	handleB := &fakeHandle{syms: map[string]Symbol{
+		"Load":   loadRegister("dup", 1, "example.com/b"),
+		"Unload": func() error { return nil },
+	}}
+	m := newTestManager(t, &fakeTransport{handle: handleA})
+	pa := NewPlugin("a.so", m)
+	if err := pa.Load(); err != nil {
+		t.Fatalf("Load a: %v", err)
+	}
+
+	mb := m.(*manager)
+	mb.options.Transport = &fakeTransport{handle: handleB}
+	pb := NewPlugin("b.so", m)
+	pb.transport = &fakeTransport{handle: handleB}
+	if err := pb.Load(); err != nil {
+		t.Fatalf("Load b: %v", err)
+	}
+
+	gotA, err := m.GetPluginByPkgPath("dup", 1, "example.com/a")
+	if err != nil || gotA != pa {
+		t.Fatalf("GetPluginByPkgPath(a) = %v, %v, want pa", gotA, err)
+	}
+	gotB, err := m.GetPluginByPkgPath("dup", 1, "example.com/b")
+	if err != nil || gotB != pb {
+		t.Fatalf("GetPluginByPkgPath(b) = %v, %v, want pb", gotB, err)
+	}
+	// GetPlugin(name) returns whichever of pa/pb loaded most recently.
+	latest, err := m.GetPlugin("dup")
+	if err != nil || latest != pb {
+		t.Fatalf("GetPlugin(\"dup\") = %v, %v, want the most recently loaded (pb)", latest, err)
+	}
+}
@@ -1,12 +1,20 @@
 //go:build (!linux && !freebsd && !darwin) || !cgo
+
 package plugin_manager
 
 import "errors"
 
-func lookup(p *Plugin, symName string) (Symbol, error) {
+// dlopenAvailable is false here because dlopen-backed Go plugins require
+// cgo on Linux, macOS, or FreeBSD; the cgo-enabled build of this package
+// provides a counterpart file that sets it true. defaultTransport uses it
+// to fall back to execTransport on platforms (notably Windows) where
+// dlopen was never an option.
+const dlopenAvailable = false
+
+func lookup(p *PluginType, symName string) (Symbol, error) {
 	return nil, errors.New("plugin: not implemented")
 }
 
-func open(name string) (*Plugin, error) {
+func open(name string) (*PluginType, error) {
 	return nil, errors.New("plugin: not implemented")
-}
\ No newline at end of file
+}
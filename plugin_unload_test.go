@@ -0,0 +1,112 @@
+package plugin_manager
+
+import (
+	"testing"
+	"time"
+)
+
+// TestUnloadDrainsInFlightCalls verifies Unload blocks until a Call already
+// in flight returns, instead of tearing the plugin down underneath it.
+func TestUnloadDrainsInFlightCalls(t *testing.T) {
+	release := make(chan struct{})
+	entered := make(chan struct{}, 1)
+	handle := &fakeHandle{syms: map[string]Symbol{
+		"Load":   loadRegister("slow", 1, "test/slow"),
+		"Unload": func() error { return nil },
+		"Slow": func() []interface{} {
+			entered <- struct{}{}
+			<-release
+			return nil
+		},
+	}}
+	m := newTestManager(t, &fakeTransport{handle: handle})
+	p := NewPlugin("slow.so", m)
+	if err := p.Load(); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	callDone := make(chan struct{})
+	go func() {
+		p.Call("Slow")
+		close(callDone)
+	}()
+	select {
+	case <-entered:
+	case <-time.After(time.Second):
+		t.Fatal("Slow never started")
+	}
+
+	unloadDone := make(chan error, 1)
+	go func() { unloadDone <- p.Unload() }()
+
+	select {
+	case <-unloadDone:
+		t.Fatal("Unload returned before the in-flight call finished")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if n := p.InFlight(); n != 1 {
+		t.Fatalf("InFlight = %d, want 1", n)
+	}
+
+	close(release)
+	<-callDone
+	select {
+	case err := <-unloadDone:
+		if err != nil {
+			t.Fatalf("Unload: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Unload never returned once the in-flight call finished")
+	}
+	if status := p.Status(); status != PluginStatusUnloaded {
+		t.Fatalf("status = %v, want PluginStatusUnloaded", status)
+	}
+}
+
+// TestGetFuncFailsFastWhileUnloading verifies GetFunc returns
+// ErrPluginUnloading as soon as Unload starts draining, instead of blocking
+// for the duration of the drain.
+func TestGetFuncFailsFastWhileUnloading(t *testing.T) {
+	release := make(chan struct{})
+	entered := make(chan struct{}, 1)
+	handle := &fakeHandle{syms: map[string]Symbol{
+		"Load":   loadRegister("slow", 1, "test/slow"),
+		"Unload": func() error { return nil },
+		"Slow": func() []interface{} {
+			entered <- struct{}{}
+			<-release
+			return nil
+		},
+	}}
+	m := newTestManager(t, &fakeTransport{handle: handle})
+	p := NewPlugin("slow.so", m)
+	if err := p.Load(); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	go p.Call("Slow")
+	select {
+	case <-entered:
+	case <-time.After(time.Second):
+		t.Fatal("Slow never started")
+	}
+
+	unloadDone := make(chan error, 1)
+	go func() { unloadDone <- p.Unload() }()
+
+	// Give Unload a moment to mark the plugin unloading and release its
+	// lock before draining (see Unload).
+	deadline := time.Now().Add(time.Second)
+	for p.Status() != PluginStatusUnloading && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if _, err := p.GetFunc("Slow"); err != ErrPluginUnloading {
+		t.Fatalf("GetFunc err = %v, want ErrPluginUnloading", err)
+	}
+
+	close(release)
+	if err := <-unloadDone; err != nil {
+		t.Fatalf("Unload: %v", err)
+	}
+}
@@ -0,0 +1,116 @@
+// Package rpc implements the child side of execTransport's wire protocol,
+// so a plugin author who can't or doesn't want to build a cgo/dlopen .so
+// can ship a plain executable instead. A typical plugin built against this
+// package looks like:
+//
+//	func main() {
+//		s := rpc.NewServer("mymodule", 0x1, "example.com/mymodule")
+//		s.HandleFunc("DoWork", doWork)
+//		s.Serve(os.Stdin, os.Stdout)
+//	}
+//
+// Serve blocks, answering the host's register handshake with the identity
+// passed to NewServer and dispatching "call" frames to handlers registered
+// with HandleFunc until the host closes the connection.
+package rpc
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"nex-p/plugin_manager/internal/wireproto"
+)
+
+// Func is the shape of a handler registered with Server.HandleFunc. It
+// receives the already-decoded call arguments and returns the results to
+// send back to the host.
+type Func func(args ...interface{}) []interface{}
+
+type wireRequest = wireproto.Request
+type wireResponse = wireproto.Response
+
+// Server answers a single execTransport connection: it identifies itself
+// to the host on the initial register handshake, then dispatches "call"
+// frames to handlers registered with HandleFunc.
+type Server struct {
+	name    string
+	version uint64
+	pkgPath string
+
+	mu    sync.RWMutex
+	funcs map[string]Func
+}
+
+// NewServer creates a Server that will identify itself to the host as
+// (name, version, pkgPath), the same triple Load's register callback takes
+// on the dlopen side.
+func NewServer(name string, version uint64, pkgPath string) *Server {
+	return &Server{
+		name:    name,
+		version: version,
+		pkgPath: pkgPath,
+		funcs:   make(map[string]Func),
+	}
+}
+
+// HandleFunc registers fn to answer calls for name.
+func (s *Server) HandleFunc(name string, fn Func) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.funcs[name] = fn
+}
+
+// Serve reads frames from r and writes responses to w until r is closed.
+// It is typically called with os.Stdin and os.Stdout from main.
+func (s *Server) Serve(r io.Reader, w io.Writer) error {
+	in := bufio.NewReader(r)
+	for {
+		var req wireRequest
+		if err := wireproto.ReadFrame(in, &req); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		switch req.Op {
+		case "register":
+			if err := wireproto.WriteFrame(w, wireResponse{Ret: []interface{}{s.name, s.version, s.pkgPath}}); err != nil {
+				return err
+			}
+		case "call":
+			resp := s.dispatch(req)
+			if err := wireproto.WriteFrame(w, resp); err != nil {
+				return err
+			}
+		default:
+			if err := wireproto.WriteFrame(w, wireResponse{Err: fmt.Sprintf("rpc: unknown op %q", req.Op)}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *Server) dispatch(req wireRequest) wireResponse {
+	s.mu.RLock()
+	fn, ok := s.funcs[req.Fn]
+	if !ok {
+		// The host tries pkgPath+"."+name before the bare name (see
+		// Plugin.GetFunc), so answer that with the handler registered under
+		// the bare name.
+		if i := strings.LastIndex(req.Fn, "."); i >= 0 {
+			fn, ok = s.funcs[req.Fn[i+1:]]
+		}
+	}
+	s.mu.RUnlock()
+	if !ok {
+		return wireResponse{Err: fmt.Sprintf("rpc: unknown function %q", req.Fn)}
+	}
+	ret, err := wireproto.MarshalArgs(fn(wireproto.UnmarshalArgs(req.Args)...))
+	if err != nil {
+		return wireResponse{Err: err.Error()}
+	}
+	return wireResponse{Ret: ret}
+}
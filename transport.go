@@ -0,0 +1,56 @@
+package plugin_manager
+
+import "errors"
+
+// PluginHandle is an opaque handle to an opened plugin, returned by
+// Transport.Open and passed back into Lookup/Close. Its concrete type is
+// owned by the Transport implementation that produced it.
+type PluginHandle any
+
+// Transport abstracts how a plugin binary is opened and how its exported
+// functions are resolved, so plugin_manager isn't locked to cgo/dlopen
+// platforms (dlopen is Linux/macOS/FreeBSD-only, and Go plugins need cgo).
+// The default is dlopenTransport where that's available and execTransport
+// everywhere else, giving platforms without dlopen a real plugin story.
+type Transport interface {
+	Open(path string) (PluginHandle, error)
+	Lookup(handle PluginHandle, name string) (Symbol, error)
+	Close(handle PluginHandle) error
+}
+
+// defaultTransport picks dlopenTransport where cgo-backed plugin loading is
+// available and execTransport elsewhere. dlopenAvailable is set by
+// whichever of plugin_stubs.go or its cgo-enabled counterpart is compiled
+// in for the current platform.
+func defaultTransport() Transport {
+	if dlopenAvailable {
+		return dlopenTransport{}
+	}
+	return execTransport{}
+}
+
+// dlopenTransport is the original dlopen-backed loader: Open/Lookup defer
+// to the platform-specific open/lookup functions (see plugin_stubs.go and
+// its cgo-enabled counterpart).
+type dlopenTransport struct{}
+
+func (dlopenTransport) Open(path string) (PluginHandle, error) {
+	return Open(path)
+}
+
+func (dlopenTransport) Lookup(handle PluginHandle, name string) (Symbol, error) {
+	p, ok := handle.(*PluginType)
+	if !ok {
+		return nil, errors.New("plugin_manager: not a dlopen plugin handle")
+	}
+	return p.Lookup(name)
+}
+
+func (dlopenTransport) Close(handle PluginHandle) error {
+	// Go plugins, and the dlopen handles backing them, cannot be closed or
+	// unloaded; see the warnings in the upstream plugin package. Plugin's
+	// own Unload lifecycle hook (if any) already ran before Close is
+	// called, so there's nothing left to release here: the OS-level handle
+	// leaks for the life of the process, same as it always has.
+	return nil
+}
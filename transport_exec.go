@@ -0,0 +1,143 @@
+package plugin_manager
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"sync"
+
+	"nex-p/plugin_manager/internal/wireproto"
+)
+
+// execTransport runs a plugin as a child process and speaks a small
+// length-prefixed JSON RPC over its stdin/stdout, so programs using
+// plugin_manager aren't limited to the cgo/dlopen platforms (see
+// plugin_manager/rpc, which the child imports to implement its half, and
+// internal/wireproto, which both sides share so they can't drift apart).
+type execTransport struct{}
+
+type wireRequest = wireproto.Request
+type wireResponse = wireproto.Response
+
+var writeFrame = wireproto.WriteFrame
+var readFrame = wireproto.ReadFrame
+
+// execHandle is the PluginHandle execTransport hands back from Open. It
+// also implements SelfRegistering: the child announces its identity as the
+// very first frame on the wire, since a host-side register callback can't
+// be marshaled across the process boundary the way Load(register) expects.
+type execHandle struct {
+	cmd     *exec.Cmd
+	in      io.WriteCloser
+	out     *bufio.Reader
+	mu      sync.Mutex // serializes request/response pairs on the wire
+	name    string
+	version uint64
+	pkgPath string
+}
+
+func (h *execHandle) PluginIdentity() (name string, version uint64, pkgPath string) {
+	return h.name, h.version, h.pkgPath
+}
+
+func (t execTransport) Open(path string) (PluginHandle, error) {
+	cmd := exec.Command(path)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("plugin_manager: exec %s: %w", path, err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("plugin_manager: exec %s: %w", path, err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("plugin_manager: exec %s: %w", path, err)
+	}
+	h := &execHandle{cmd: cmd, in: stdin, out: bufio.NewReader(stdout)}
+
+	if err := writeFrame(h.in, wireRequest{Op: "register"}); err != nil {
+		cmd.Process.Kill()
+		return nil, fmt.Errorf("plugin_manager: exec %s: register: %w", path, err)
+	}
+	var resp wireResponse
+	if err := readFrame(h.out, &resp); err != nil {
+		cmd.Process.Kill()
+		return nil, fmt.Errorf("plugin_manager: exec %s: register: %w", path, err)
+	}
+	if resp.Err != "" {
+		cmd.Process.Kill()
+		return nil, fmt.Errorf("plugin_manager: exec %s: register: %s", path, resp.Err)
+	}
+	if len(resp.Ret) != 3 {
+		cmd.Process.Kill()
+		return nil, fmt.Errorf("plugin_manager: exec %s: register: expected [name, version, pkgPath], got %v", path, resp.Ret)
+	}
+	name, _ := resp.Ret[0].(string)
+	version, _ := toUint64(resp.Ret[1])
+	pkgPath, _ := resp.Ret[2].(string)
+	h.name, h.version, h.pkgPath = name, version, pkgPath
+	return h, nil
+}
+
+func (t execTransport) Lookup(handle PluginHandle, name string) (Symbol, error) {
+	h, ok := handle.(*execHandle)
+	if !ok {
+		return nil, errors.New("plugin_manager: not an exec plugin handle")
+	}
+	// There is no way to know in advance whether the child can serve name;
+	// the first call will surface an unknown-function error from the
+	// wireResponse.Err of the rpc package's dispatcher if it can't.
+	return &execSymbol{handle: h, name: name}, nil
+}
+
+func (t execTransport) Close(handle PluginHandle) error {
+	h, ok := handle.(*execHandle)
+	if !ok {
+		return errors.New("plugin_manager: not an exec plugin handle")
+	}
+	h.in.Close()
+	return h.cmd.Wait()
+}
+
+// execSymbol is the Symbol Lookup returns for execTransport; it implements
+// rpcCallable so Plugin.buildCallable routes calls over the wire instead of
+// through reflect.
+type execSymbol struct {
+	handle *execHandle
+	name   string
+}
+
+func (s *execSymbol) call(args []interface{}) ([]interface{}, error) {
+	wireArgs, err := wireproto.MarshalArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	s.handle.mu.Lock()
+	defer s.handle.mu.Unlock()
+	if err := writeFrame(s.handle.in, wireRequest{Op: "call", Fn: s.name, Args: wireArgs}); err != nil {
+		return nil, fmt.Errorf("plugin_manager: call %s: %w", s.name, err)
+	}
+	var resp wireResponse
+	if err := readFrame(s.handle.out, &resp); err != nil {
+		return nil, fmt.Errorf("plugin_manager: call %s: %w", s.name, err)
+	}
+	if resp.Err != "" {
+		return nil, errors.New(resp.Err)
+	}
+	return wireproto.UnmarshalArgs(resp.Ret), nil
+}
+
+func toUint64(v interface{}) (uint64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return uint64(n), true
+	case uint64:
+		return n, true
+	case int64:
+		return uint64(n), true
+	default:
+		return 0, false
+	}
+}
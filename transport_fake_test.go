@@ -0,0 +1,68 @@
+package plugin_manager
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+)
+
+// fakeHandle is a PluginHandle backed by an in-memory symbol table, letting
+// tests exercise Plugin's lifecycle and call paths without a real
+// dlopen/exec transport.
+type fakeHandle struct {
+	syms map[string]Symbol
+}
+
+// fakeTransport is a Transport over fakeHandle, for tests.
+type fakeTransport struct {
+	openErr error
+	handle  *fakeHandle
+	closed  int32
+}
+
+func (t *fakeTransport) Open(path string) (PluginHandle, error) {
+	if t.openErr != nil {
+		return nil, t.openErr
+	}
+	return t.handle, nil
+}
+
+func (t *fakeTransport) Lookup(handle PluginHandle, name string) (Symbol, error) {
+	h, ok := handle.(*fakeHandle)
+	if !ok {
+		return nil, errors.New("fakeTransport: not a fake handle")
+	}
+	f, ok := h.syms[name]
+	if !ok {
+		return nil, fmt.Errorf("fakeTransport: symbol %q not found", name)
+	}
+	return f, nil
+}
+
+func (t *fakeTransport) Close(handle PluginHandle) error {
+	atomic.AddInt32(&t.closed, 1)
+	return nil
+}
+
+// newTestManager returns a running Manager backed by transport, for tests
+// that need Plugin.Load to succeed without a real plugin binary.
+func newTestManager(t *testing.T, transport Transport) Manager {
+	t.Helper()
+	m, err := NewManager(ManagerOptions{Transport: transport})
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	if err := m.Run(); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	return m
+}
+
+// loadRegister is the Load(register) symbol every fakeHandle in these tests
+// exports, self-registering under (name, version, pkgPath).
+func loadRegister(name string, version uint64, pkgPath string) Symbol {
+	return func(register func(name string, version uint64, pkgPath string) error) error {
+		return register(name, version, pkgPath)
+	}
+}
@@ -0,0 +1,193 @@
+package plugin_manager
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// PluginEventKind identifies what happened to a plugin, as reported on
+// Manager's optional Events channel.
+type PluginEventKind int
+
+const (
+	PluginLoaded PluginEventKind = iota
+	PluginReloaded
+	PluginReloadFailed
+	PluginUnloaded
+)
+
+// PluginEvent reports a plugin lifecycle change triggered by a watched
+// directory, so callers can rebuild anything (e.g. a routing table) that
+// caches GetFunc results.
+type PluginEvent struct {
+	Kind   PluginEventKind
+	Plugin *Plugin
+	Err    error // set on PluginReloadFailed
+}
+
+// defaultReloadDebounce is used when ManagerOptions.ReloadDebounce is zero.
+const defaultReloadDebounce = 500 * time.Millisecond
+
+// WatchDir monitors dir for plugin binaries (files named *.so) being created
+// or rewritten, reloading the corresponding Plugin on change. Events within
+// ReloadDebounce of each other for the same path are coalesced into a single
+// reload, so editors that write-then-rename a binary don't trigger two.
+func (m *manager) WatchDir(dir string) error {
+	m.watchOnce.Do(func() {
+		m.watchInitErr = m.initWatcher()
+	})
+	if m.watchInitErr != nil {
+		return m.watchInitErr
+	}
+	if err := m.watcher.Add(dir); err != nil {
+		return fmt.Errorf("plugin_manager: watch %s: %w", dir, err)
+	}
+	return nil
+}
+
+func (m *manager) initWatcher() error {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("plugin_manager: watch: %w", err)
+	}
+	// Close reads m.watcher under m.Lock(); take it here too, since WatchDir
+	// (and thus initWatcher, via watchOnce) can run concurrently with Close.
+	m.Lock()
+	m.watcher = w
+	m.Unlock()
+	go m.watchLoop()
+	return nil
+}
+
+func (m *manager) watchLoop() {
+	for {
+		select {
+		case ev, ok := <-m.watcher.Events:
+			if !ok {
+				return
+			}
+			if !strings.HasSuffix(ev.Name, ".so") {
+				continue
+			}
+			if ev.Op&(fsnotify.Write|fsnotify.Create) == 0 {
+				continue
+			}
+			m.debounce(ev.Name)
+		case err, ok := <-m.watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Print("plugin_manager: watch error: ", err)
+		}
+	}
+}
+
+// debounce coalesces repeated events for path into a single reloadPath call
+// after ReloadDebounce (or defaultReloadDebounce) of quiet.
+func (m *manager) debounce(path string) {
+	d := m.options.ReloadDebounce
+	if d <= 0 {
+		d = defaultReloadDebounce
+	}
+	m.Lock()
+	defer m.Unlock()
+	if m.pending == nil {
+		m.pending = make(map[string]*time.Timer)
+	}
+	if t, ok := m.pending[path]; ok {
+		t.Reset(d)
+		return
+	}
+	m.pending[path] = time.AfterFunc(d, func() {
+		m.Lock()
+		delete(m.pending, path)
+		m.Unlock()
+		m.reloadPath(path)
+	})
+}
+
+// reloadPath loads path into a fresh Plugin (or, if the last attempt at
+// path failed, retries that same Plugin via Reload) and, only once that
+// succeeds, swaps out whichever plugin was previously serving path. A
+// failed reload leaves the previous plugin live and serving traffic; the
+// failed Plugin itself is retained via recordFailed so its
+// PluginStatusFailed/LoadError stays queryable through FailedPlugin and
+// the next event for path retries it directly instead of paying full
+// dlopen/exec-spawn cost in a throwaway Plugin with no memory of the
+// failure.
+func (m *manager) reloadPath(path string) {
+	m.RLock()
+	old := m.byPath[path]
+	next := m.failedByPath[path]
+	m.RUnlock()
+
+	var err error
+	if next != nil {
+		next.replaces = old
+		err = next.Reload()
+	} else {
+		next = NewPlugin(path, m)
+		next.replaces = old
+		err = next.Load()
+	}
+	if err != nil {
+		log.Print("plugin_manager: watch: reload ", path, " failed, keeping previous plugin live: ", err)
+		m.recordFailed(path, next)
+		m.emit(PluginEvent{Kind: PluginReloadFailed, Plugin: next, Err: err})
+		return
+	}
+	m.clearFailed(path)
+	if old == nil {
+		m.emit(PluginEvent{Kind: PluginLoaded, Plugin: next})
+		return
+	}
+	if err := old.Unload(); err != nil {
+		log.Print("plugin_manager: watch: unload previous plugin for ", path, ": ", err)
+	}
+	m.emit(PluginEvent{Kind: PluginReloaded, Plugin: next})
+	m.emit(PluginEvent{Kind: PluginUnloaded, Plugin: old})
+}
+
+// recordFailed retains the Plugin from a failed watch-driven Load/Reload at
+// path; see reloadPath and FailedPlugin.
+func (m *manager) recordFailed(path string, p *Plugin) {
+	m.Lock()
+	defer m.Unlock()
+	m.failedByPath[path] = p
+}
+
+// clearFailed drops path's retained failed Plugin, if any, once a reload at
+// path succeeds.
+func (m *manager) clearFailed(path string) {
+	m.Lock()
+	defer m.Unlock()
+	delete(m.failedByPath, path)
+}
+
+// FailedPlugin returns the Plugin from the most recent failed watch-driven
+// Load/Reload at path, or nil if its last attempt succeeded or path was
+// never watched. Check its LoadError for why.
+func (m *manager) FailedPlugin(path string) *Plugin {
+	m.RLock()
+	defer m.RUnlock()
+	return m.failedByPath[path]
+}
+
+func (m *manager) emit(ev PluginEvent) {
+	if m.options.Events == nil {
+		return
+	}
+	select {
+	case m.options.Events <- ev:
+	default:
+		log.Print("plugin_manager: dropping plugin event, Events channel is full")
+	}
+}
+
+func (m *manager) Events() <-chan PluginEvent {
+	return m.options.Events
+}
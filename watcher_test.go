@@ -0,0 +1,195 @@
+package plugin_manager
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestWatchDirDebouncesWritesIntoOneReload verifies two writes to the same
+// path within ReloadDebounce of each other trigger a single load, and that
+// the resulting plugin is registered and loaded.
+func TestWatchDirDebouncesWritesIntoOneReload(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "watched.so")
+	if err := os.WriteFile(path, []byte("v1"), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	var loads int32
+	handle := &fakeHandle{syms: map[string]Symbol{
+		"Load": func(register func(name string, version uint64, pkgPath string) error) error {
+			atomic.AddInt32(&loads, 1)
+			return register("watched", 1, "test/watched")
+		},
+		"Unload": func() error { return nil },
+	}}
+	events := make(chan PluginEvent, 10)
+	m, err := NewManager(ManagerOptions{
+		Transport:      &fakeTransport{handle: handle},
+		ReloadDebounce: 20 * time.Millisecond,
+		Events:         events,
+	})
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	if err := m.Run(); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	defer m.Close()
+	if err := m.WatchDir(dir); err != nil {
+		t.Fatalf("WatchDir: %v", err)
+	}
+
+	// Two writes within the debounce window should coalesce into one load.
+	if err := os.WriteFile(path, []byte("v2"), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if err := os.WriteFile(path, []byte("v3"), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	select {
+	case ev := <-events:
+		if ev.Kind != PluginLoaded {
+			t.Fatalf("event kind = %v, want PluginLoaded", ev.Kind)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no PluginLoaded event within 2s")
+	}
+	// Give any stray second reload a chance to fire before asserting the count.
+	time.Sleep(100 * time.Millisecond)
+	if n := atomic.LoadInt32(&loads); n != 1 {
+		t.Fatalf("loads = %d, want 1 (debounced)", n)
+	}
+	p, err := m.GetPlugin("watched")
+	if err != nil || p.Status() != PluginStatusLoaded {
+		t.Fatalf("GetPlugin(watched) = %v, %v, want a loaded plugin", p, err)
+	}
+}
+
+// TestReloadFailureKeepsOldPluginAndRetriesFailedOne verifies a failed
+// reload leaves the previous plugin serving traffic, records the failure
+// via FailedPlugin, and retries the same failed Plugin (rather than a fresh
+// throwaway one) the next time the path changes.
+func TestReloadFailureKeepsOldPluginAndRetriesFailedOne(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "flaky.so")
+	if err := os.WriteFile(path, []byte("v1"), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	var attempts int32
+	handle := &fakeHandle{syms: map[string]Symbol{
+		"Load": func(register func(name string, version uint64, pkgPath string) error) error {
+			if atomic.AddInt32(&attempts, 1) == 1 {
+				return register("flaky", 1, "test/flaky")
+			}
+			return errors.New("load: simulated failure on reload")
+		},
+		"Unload": func() error { return nil },
+	}}
+	events := make(chan PluginEvent, 10)
+	m, err := NewManager(ManagerOptions{
+		Transport:      &fakeTransport{handle: handle},
+		ReloadDebounce: 5 * time.Millisecond,
+		Events:         events,
+	})
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	if err := m.Run(); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	defer m.Close()
+	if err := m.WatchDir(dir); err != nil {
+		t.Fatalf("WatchDir: %v", err)
+	}
+	// WatchDir only reacts to filesystem events from here on; v1 above
+	// predates it, so write again to trigger the first (successful) load.
+	if err := os.WriteFile(path, []byte("v1.1"), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	select {
+	case ev := <-events:
+		if ev.Kind != PluginLoaded {
+			t.Fatalf("event kind = %v, want PluginLoaded", ev.Kind)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no initial PluginLoaded event within 2s")
+	}
+	original, err := m.GetPlugin("flaky")
+	if err != nil {
+		t.Fatalf("GetPlugin(flaky): %v", err)
+	}
+
+	if err := os.WriteFile(path, []byte("v2"), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	select {
+	case ev := <-events:
+		if ev.Kind != PluginReloadFailed {
+			t.Fatalf("event kind = %v, want PluginReloadFailed", ev.Kind)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no PluginReloadFailed event within 2s")
+	}
+
+	if got, err := m.GetPlugin("flaky"); err != nil || got != original {
+		t.Fatalf("GetPlugin(flaky) = %v, %v, want the original plugin still serving", got, err)
+	}
+	mgr := m.(*manager)
+	failed := mgr.FailedPlugin(path)
+	if failed == nil {
+		t.Fatal("FailedPlugin = nil, want the failed reload attempt")
+	}
+	if failed.LoadError() == nil {
+		t.Fatal("FailedPlugin.LoadError() = nil, want the simulated failure")
+	}
+
+	// The next change to path retries that same failed Plugin.
+	if err := os.WriteFile(path, []byte("v3"), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	select {
+	case ev := <-events:
+		if ev.Kind != PluginReloadFailed || ev.Plugin != failed {
+			t.Fatalf("event = %+v, want PluginReloadFailed for the retained failed plugin", ev)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no PluginReloadFailed event for the retry within 2s")
+	}
+	if got := mgr.FailedPlugin(path); got != failed {
+		t.Fatalf("FailedPlugin = %v, want the same failed plugin retried", got)
+	}
+}
+
+// TestWatchDirAndCloseConcurrently exercises WatchDir racing Close from
+// separate goroutines, so `go test -race` catches a regression of the
+// initWatcher/Close data race on m.watcher.
+func TestWatchDirAndCloseConcurrently(t *testing.T) {
+	dir := t.TempDir()
+	m, err := NewManager(ManagerOptions{Transport: &fakeTransport{handle: &fakeHandle{syms: map[string]Symbol{}}}})
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	if err := m.Run(); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		m.WatchDir(dir)
+	}()
+	if err := m.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	<-done
+}
+